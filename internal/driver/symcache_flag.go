@@ -0,0 +1,66 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package driver wires the command-line flags pprof's front end
+// parses to the mechanisms in internal/symbolizer that implement
+// them.
+package driver
+
+import "github.com/google/pprof/internal/symbolizer"
+
+// flagBoolSetter is the subset of plugin.FlagSet that RegisterFlags
+// needs; plugin.Options.Flagset satisfies it.
+type flagBoolSetter interface {
+	Bool(name string, def bool, usage string) *bool
+}
+
+// printer is the subset of plugin.UI that MaybeRunMaintenance needs;
+// plugin.Options.UI satisfies it.
+type printer interface {
+	Print(...interface{})
+}
+
+// Flags holds the parsed values of the flags RegisterFlags defines.
+type Flags struct {
+	// SymCachePrune is set by "-symcache-prune": rather than fetching
+	// and rendering a profile, delete every entry in the on-disk
+	// symbolization cache and exit.
+	SymCachePrune *bool
+}
+
+// RegisterFlags defines the maintenance flags this package owns on
+// flag. Call it before the command line is parsed.
+func RegisterFlags(flag flagBoolSetter) *Flags {
+	return &Flags{
+		SymCachePrune: flag.Bool("symcache-prune", false, "Delete every entry in the on-disk symbolization cache and exit"),
+	}
+}
+
+// MaybeRunMaintenance runs whichever maintenance command f selects
+// against cache (DefaultSymCache if nil) and reports whether it
+// handled the invocation, so the caller can return immediately
+// instead of going on to fetch and render a profile.
+func MaybeRunMaintenance(f *Flags, cache *symbolizer.SymCache, ui printer) (handled bool, err error) {
+	if f == nil || f.SymCachePrune == nil || !*f.SymCachePrune {
+		return false, nil
+	}
+	if cache == nil {
+		cache = symbolizer.DefaultSymCache()
+	}
+	if err := cache.Prune(); err != nil {
+		return true, err
+	}
+	ui.Print("pprof: symbolization cache pruned")
+	return true, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/pprof/internal/symbolizer"
+)
+
+// fakeFlagSet is a flagBoolSetter that just remembers the default it
+// was given, standing in for plugin.Options.Flagset.
+type fakeFlagSet struct {
+	vals map[string]*bool
+}
+
+func (f *fakeFlagSet) Bool(name string, def bool, usage string) *bool {
+	if f.vals == nil {
+		f.vals = make(map[string]*bool)
+	}
+	v := def
+	f.vals[name] = &v
+	return &v
+}
+
+// fakeUI is a printer that records what was printed.
+type fakeUI struct {
+	printed []string
+}
+
+func (u *fakeUI) Print(args ...interface{}) {
+	u.printed = append(u.printed, fmt.Sprint(args...))
+}
+
+func TestRegisterFlagsDefault(t *testing.T) {
+	flags := RegisterFlags(&fakeFlagSet{})
+	if flags.SymCachePrune == nil || *flags.SymCachePrune {
+		t.Fatalf("SymCachePrune default = %v, want false", flags.SymCachePrune)
+	}
+}
+
+func TestMaybeRunMaintenanceNotRequested(t *testing.T) {
+	unset := false
+	ui := &fakeUI{}
+	handled, err := MaybeRunMaintenance(&Flags{SymCachePrune: &unset}, nil, ui)
+	if err != nil {
+		t.Fatalf("MaybeRunMaintenance returned error: %v", err)
+	}
+	if handled {
+		t.Fatal("MaybeRunMaintenance reported handled when -symcache-prune wasn't set")
+	}
+	if len(ui.printed) != 0 {
+		t.Fatalf("unexpected output: %v", ui.printed)
+	}
+}
+
+func TestMaybeRunMaintenancePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := &symbolizer.SymCache{Dir: dir}
+	if err := cache.Put("build1", 0x1000, nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	set := true
+	ui := &fakeUI{}
+	handled, err := MaybeRunMaintenance(&Flags{SymCachePrune: &set}, cache, ui)
+	if err != nil {
+		t.Fatalf("MaybeRunMaintenance returned error: %v", err)
+	}
+	if !handled {
+		t.Fatal("MaybeRunMaintenance reported not handled when -symcache-prune was set")
+	}
+	if _, ok := cache.Get("build1", 0x1000); ok {
+		t.Fatal("cache entry survived MaybeRunMaintenance")
+	}
+	if len(ui.printed) != 1 {
+		t.Fatalf("printed = %v, want one line", ui.printed)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+// DebugInfoLocator resolves where the debug info for a profiled
+// binary mapping actually lives, for toolchains that ship it apart
+// from the mapped file itself: macOS dSYM bundles, and ELF
+// .gnu_debuglink / build-id debug directories.
+type DebugInfoLocator interface {
+	// Locate returns candidate file paths, in preference order, that
+	// might hold the debug info for the binary at file with the given
+	// build ID (which may be empty). Symbolizer opens each in turn,
+	// stopping at the first one that opens and whose build ID (if any)
+	// matches.
+	Locate(file, buildID string) []string
+}
@@ -0,0 +1,39 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+// DemangleOptions controls how a Demangler simplifies a symbol name.
+type DemangleOptions struct {
+	// Mode is the simplification level to apply to the demangled
+	// name: "" (parameters and template arguments stripped),
+	// "templates" (template arguments kept, parameters stripped), or
+	// "full" (nothing stripped). It mirrors the existing
+	// -symbolize=demangle=... mode tokens.
+	Mode string
+}
+
+// Demangler converts mangled or otherwise compiler/linker generated
+// symbol names into a form suitable for display. Symbolizer consults
+// a list of registered Demanglers for each function name, in order,
+// using the first one whose CanDemangle returns true.
+type Demangler interface {
+	// CanDemangle reports whether sym looks like a name this
+	// Demangler knows how to simplify.
+	CanDemangle(sym string) bool
+
+	// Demangle simplifies sym according to opts. It returns an error
+	// if sym turns out not to be a name this Demangler can handle.
+	Demangle(sym string, opts DemangleOptions) (string, error)
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/google/pprof/internal/plugin"
+)
+
+// DefaultDebugInfoLocator returns the built-in plugin.DebugInfoLocator,
+// which knows the conventions production toolchains use to ship debug
+// info apart from the binary itself. Callers with a custom symbol
+// store (e.g. one backed by S3) can set Symbolizer.DebugInfo to their
+// own implementation instead.
+func DefaultDebugInfoLocator() plugin.DebugInfoLocator {
+	return debugInfoLocator{}
+}
+
+type debugInfoLocator struct{}
+
+func (debugInfoLocator) Locate(file, buildID string) []string {
+	dir := filepath.Dir(file)
+	base := filepath.Base(file)
+
+	var candidates []string
+
+	// macOS: a dSYM bundle next to the binary, then the search
+	// directories named by PPROF_DSYM_PATH / DEBUG_FILE_DIRECTORY.
+	candidates = append(candidates, filepath.Join(file+".dSYM", "Contents", "Resources", "DWARF", base))
+	for _, env := range []string{"PPROF_DSYM_PATH", "DEBUG_FILE_DIRECTORY"} {
+		for _, root := range filepath.SplitList(os.Getenv(env)) {
+			candidates = append(candidates,
+				filepath.Join(root, base+".dSYM", "Contents", "Resources", "DWARF", base),
+				filepath.Join(root, base))
+		}
+	}
+
+	// ELF: the .gnu_debuglink convention of shipping the debug info
+	// under a ".debug"-suffixed name, either under a debug root that
+	// mirrors the binary's own path, or beside it in a ".debug"
+	// directory.
+	candidates = append(candidates,
+		filepath.Join("/usr/lib/debug", file+".debug"),
+		filepath.Join(dir, ".debug", base+".debug"))
+
+	// ELF build-id debug directory: <debug-dir>/.build-id/xx/yyyy....debug
+	if len(buildID) > 2 {
+		candidates = append(candidates,
+			filepath.Join("/usr/lib/debug", ".build-id", buildID[:2], buildID[2:]+".debug"))
+	}
+
+	return candidates
+}
@@ -0,0 +1,136 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestDebugInfoLocatorCandidates(t *testing.T) {
+	locator := DefaultDebugInfoLocator()
+	candidates := locator.Locate("/bin/foo", "0123456789abcdef")
+
+	wantSuffixes := []string{
+		filepath.Join("foo.dSYM", "Contents", "Resources", "DWARF", "foo"),
+		filepath.Join("/usr/lib/debug", "/bin/foo.debug"),
+		filepath.Join("/bin", ".debug", "foo.debug"),
+		filepath.Join("/usr/lib/debug", ".build-id", "01", "23456789abcdef.debug"),
+	}
+	for _, want := range wantSuffixes {
+		found := false
+		for _, c := range candidates {
+			if strings.HasSuffix(c, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no candidate ended in %q; candidates: %v", want, candidates)
+		}
+	}
+}
+
+func TestDebugInfoLocatorShortBuildID(t *testing.T) {
+	locator := DefaultDebugInfoLocator()
+	// A build ID too short to split into a 2-hex-digit directory and a
+	// remainder shouldn't produce a build-id candidate.
+	candidates := locator.Locate("/bin/foo", "ab")
+	for _, c := range candidates {
+		if strings.Contains(c, ".build-id") {
+			t.Errorf("unexpected build-id candidate for a 2-char build ID: %q", c)
+		}
+	}
+}
+
+func TestOpenMappingFileOwnFile(t *testing.T) {
+	obj := &fakeObjTool{files: map[string]*fakeObjFile{
+		"/bin/foo": {name: "/bin/foo", buildID: "abc"},
+	}}
+	m := &profile.Mapping{File: "/bin/foo", BuildID: "abc"}
+
+	f, path, outcome, err := openMappingFile(obj, noopLocator{}, m)
+	if err != nil {
+		t.Fatalf("openMappingFile failed: %v", err)
+	}
+	defer f.Close()
+	if path != "/bin/foo" {
+		t.Errorf("path = %q, want %q", path, "/bin/foo")
+	}
+	if outcome != OutcomeSymbolized {
+		t.Errorf("outcome = %v, want %v", outcome, OutcomeSymbolized)
+	}
+}
+
+func TestOpenMappingFileFallsBackToLocatorCandidate(t *testing.T) {
+	obj := &fakeObjTool{files: map[string]*fakeObjFile{
+		"/debug/foo.debug": {name: "/debug/foo.debug", buildID: "abc"},
+	}}
+	m := &profile.Mapping{File: "/bin/foo", BuildID: "abc"}
+
+	f, path, outcome, err := openMappingFile(obj, fakeLocator{candidates: []string{"/debug/foo.debug"}}, m)
+	if err != nil {
+		t.Fatalf("openMappingFile failed: %v", err)
+	}
+	defer f.Close()
+	if path != "/debug/foo.debug" {
+		t.Errorf("path = %q, want %q", path, "/debug/foo.debug")
+	}
+	if outcome != OutcomeSymbolized {
+		t.Errorf("outcome = %v, want %v", outcome, OutcomeSymbolized)
+	}
+}
+
+func TestOpenMappingFileBuildIDMismatch(t *testing.T) {
+	obj := &fakeObjTool{files: map[string]*fakeObjFile{
+		"/bin/foo": {name: "/bin/foo", buildID: "different"},
+	}}
+	m := &profile.Mapping{File: "/bin/foo", BuildID: "abc"}
+
+	_, _, outcome, err := openMappingFile(obj, noopLocator{}, m)
+	if err == nil {
+		t.Fatal("expected an error for a build ID mismatch")
+	}
+	if outcome != OutcomeBuildIDMismatch {
+		t.Errorf("outcome = %v, want %v", outcome, OutcomeBuildIDMismatch)
+	}
+}
+
+func TestOpenMappingFileNoneOpen(t *testing.T) {
+	obj := &fakeObjTool{files: map[string]*fakeObjFile{}}
+	m := &profile.Mapping{File: "/bin/foo", BuildID: "abc"}
+
+	_, _, outcome, err := openMappingFile(obj, noopLocator{}, m)
+	if err == nil {
+		t.Fatal("expected an error when no candidate opens")
+	}
+	if outcome != OutcomeOpenError {
+		t.Errorf("outcome = %v, want %v", outcome, OutcomeOpenError)
+	}
+}
+
+// noopLocator offers no fallback candidates.
+type noopLocator struct{}
+
+func (noopLocator) Locate(file, buildID string) []string { return nil }
+
+// fakeLocator offers a fixed set of fallback candidates, ignoring its
+// arguments.
+type fakeLocator struct{ candidates []string }
+
+func (l fakeLocator) Locate(file, buildID string) []string { return l.candidates }
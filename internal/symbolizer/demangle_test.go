@@ -0,0 +1,99 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestLooksLikeGoGeneric(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		want bool
+	}{
+		{"pkg/path.Func[go.shape.int_0]", true},
+		{"pkg/path.Type[go.shape.int_0].Method", true},
+		{"main.main", false},
+		{"_ZN3foo3barEv", false},
+		{"std::vector<int>::push_back", false},
+		{"ns::Type<int>::method", false},
+	} {
+		if got := looksLikeGoGeneric(c.name); got != c.want {
+			t.Errorf("looksLikeGoGeneric(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDemangleGoGeneric(t *testing.T) {
+	for _, c := range []struct {
+		name          string
+		demanglerMode string
+		want          string
+	}{
+		// An instantiation list made up entirely of go.shape.*
+		// placeholders strips down to nothing, but still collapses to
+		// "[...]" the way the Go runtime prints it, not "[]".
+		{"pkg/path.Func[go.shape.int_0]", "", "pkg/path.Func[...]"},
+		{"pkg/path.Type[go.shape.int_0].Method", "", "pkg/path.Type[...].Method"},
+		{"pkg/path.Func[go.shape.int_0]", "templates", "pkg/path.Func[...]"},
+		// A non-generic name passes through untouched.
+		{"pkg/path.NotGeneric", "", "pkg/path.NotGeneric"},
+		// "full" mode keeps the instantiation args, but still strips
+		// go.shape.* placeholders.
+		{"pkg/path.Func[go.shape.int_0]", "full", "pkg/path.Func[]"},
+		{"pkg/path.Func[int,go.shape.int_0]", "full", "pkg/path.Func[int]"},
+		// A real (non-placeholder) instantiation argument still
+		// collapses to "[...]" outside "full" mode.
+		{"pkg/path.Func[int]", "", "pkg/path.Func[...]"},
+	} {
+		if got := demangleGoGeneric(c.name, c.demanglerMode); got != c.want {
+			t.Errorf("demangleGoGeneric(%q, %q) = %q, want %q", c.name, c.demanglerMode, got, c.want)
+		}
+	}
+}
+
+func TestDemangle(t *testing.T) {
+	prof := &profile.Profile{
+		Function: []*profile.Function{
+			{Name: "pkg/path.Func[go.shape.int_0]", SystemName: "pkg/path.Func[go.shape.int_0]"},
+			{Name: "_ZN3foo3barEv", SystemName: "_ZN3foo3barEv"},
+		},
+	}
+
+	Demangle(prof, false, "", nil)
+
+	if got, want := prof.Function[0].Name, "pkg/path.Func[...]"; got != want {
+		t.Errorf("Function[0].Name = %q, want %q", got, want)
+	}
+	if got, want := prof.Function[1].Name, "foo::bar"; got != want {
+		t.Errorf("Function[1].Name = %q, want %q", got, want)
+	}
+}
+
+func TestDemangleNoneMode(t *testing.T) {
+	prof := &profile.Profile{
+		Function: []*profile.Function{
+			{Name: "_ZN3foo3barEv", SystemName: "_ZN3foo3barEv"},
+		},
+	}
+
+	Demangle(prof, false, "none", nil)
+
+	if got, want := prof.Function[0].Name, "_ZN3foo3barEv"; got != want {
+		t.Errorf("demangle=none changed Name to %q, want unchanged %q", got, want)
+	}
+}
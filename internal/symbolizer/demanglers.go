@@ -0,0 +1,186 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/internal/plugin"
+	"github.com/ianlancetaylor/demangle"
+)
+
+// DefaultDemanglers returns the built-in set of demanglers, in the
+// order Symbolizer consults them when none are explicitly configured:
+// Rust, C++, Swift, and Go generics. Rust comes before C++ because the
+// legacy Rust mangling scheme ("_ZN...17h<hash>E") is valid Itanium
+// C++ mangling too, and cppDemangler would otherwise "successfully"
+// demangle it without ever stripping the trailing disambiguator hash.
+func DefaultDemanglers() []plugin.Demangler {
+	return []plugin.Demangler{
+		rustDemangler{},
+		cppDemangler{},
+		swiftDemangler{},
+		goDemangler{},
+	}
+}
+
+// cppDemangler demangles Itanium-mangled C++ symbol names using
+// ianlancetaylor/demangle.
+type cppDemangler struct{}
+
+func (cppDemangler) CanDemangle(sym string) bool {
+	return strings.HasPrefix(sym, "_Z") || strings.HasPrefix(sym, "_GLOBAL_")
+}
+
+func (cppDemangler) Demangle(sym string, opts plugin.DemangleOptions) (string, error) {
+	var options []demangle.Option
+	switch opts.Mode {
+	case "", "auto": // demangled, simplified: no parameters, no templates, no return type
+		options = []demangle.Option{demangle.NoParams, demangle.NoTemplateParams}
+	case "templates": // demangled, simplified: no parameters, no return type
+		options = []demangle.Option{demangle.NoParams}
+	case "full":
+		options = []demangle.Option{demangle.NoClones}
+	}
+	if demangled := demangle.Filter(sym, options...); demangled != sym {
+		return demangled, nil
+	}
+	return "", fmt.Errorf("not a mangled C++ name: %s", sym)
+}
+
+// legacyRustHash matches the 16-hex-digit disambiguating hash rustc
+// appends to every symbol in the legacy mangling scheme, e.g.
+// "17h0123456789abcdefE".
+var legacyRustHash = regexp.MustCompile(`17h[0-9a-f]{16}E$`)
+
+// rustDemangler demangles both the legacy Itanium-derived mangling
+// ("_ZN...17h<hash>E") and the v0 scheme ("_R...").
+type rustDemangler struct{}
+
+func (rustDemangler) CanDemangle(sym string) bool {
+	if strings.HasPrefix(sym, "_R") {
+		return true
+	}
+	return strings.HasPrefix(sym, "_ZN") && legacyRustHash.MatchString(sym)
+}
+
+func (rustDemangler) Demangle(sym string, opts plugin.DemangleOptions) (string, error) {
+	if strings.HasPrefix(sym, "_R") {
+		return demangleRustV0(sym, opts)
+	}
+	return demangleRustLegacy(sym, opts)
+}
+
+// demangleRustLegacy decodes the legacy "_ZN<len><ident>...E" scheme
+// shared with Itanium C++, then, unless opts.Mode is "full", drops the
+// trailing disambiguator hash rustc appends to every symbol.
+func demangleRustLegacy(sym string, opts plugin.DemangleOptions) (string, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(sym, "_ZN"), "E")
+	var parts []string
+	for len(body) > 0 {
+		i := 0
+		for i < len(body) && body[i] >= '0' && body[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return "", fmt.Errorf("malformed rust symbol: %s", sym)
+		}
+		n, err := strconv.Atoi(body[:i])
+		if err != nil || i+n > len(body) {
+			return "", fmt.Errorf("malformed rust symbol: %s", sym)
+		}
+		parts = append(parts, body[i:i+n])
+		body = body[i+n:]
+	}
+	if len(parts) > 0 && opts.Mode != "full" {
+		if last := parts[len(parts)-1]; strings.HasPrefix(last, "h") && len(last) == 17 {
+			parts = parts[:len(parts)-1]
+		}
+	}
+	return strings.Join(parts, "::"), nil
+}
+
+// demangleRustV0 decodes just enough of the v0 mangling scheme
+// (see rustc-dev-guide's "Symbol Mangling") to recover the path
+// components of a function or method name; it does not decode generic
+// arguments or const generics.
+func demangleRustV0(sym string, opts plugin.DemangleOptions) (string, error) {
+	body := strings.TrimPrefix(sym, "_R")
+	// Skip the nested-name tags ("N" plus a namespace letter) and the
+	// crate-root tag ("C") that precede the path's identifiers.
+	for len(body) >= 2 && body[0] == 'N' {
+		body = body[2:]
+	}
+	body = strings.TrimPrefix(body, "C")
+	var parts []string
+	for len(body) > 0 {
+		if body[0] == 's' { // "s<base-62-number>_" disambiguator
+			end := strings.IndexByte(body, '_')
+			if end < 0 {
+				break
+			}
+			body = body[end+1:]
+		}
+		if len(body) == 0 || body[0] < '0' || body[0] > '9' {
+			break
+		}
+		i := 0
+		for i < len(body) && body[i] >= '0' && body[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(body[:i])
+		if err != nil || i+n > len(body) {
+			break
+		}
+		parts = append(parts, body[i:i+n])
+		body = body[i+n:]
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("unsupported rust v0 symbol: %s", sym)
+	}
+	return strings.Join(parts, "::"), nil
+}
+
+// swiftDemangler recognizes Swift's mangled-name prefixes. Absent the
+// Swift demangling library, it only strips the prefix rather than
+// fully decoding the name; a fuller decoder can be layered in later by
+// registering a richer plugin.Demangler in Symbolizer.Demanglers ahead
+// of this one.
+type swiftDemangler struct{}
+
+func (swiftDemangler) CanDemangle(sym string) bool {
+	return strings.HasPrefix(sym, "_$s") || strings.HasPrefix(sym, "_T0")
+}
+
+func (swiftDemangler) Demangle(sym string, opts plugin.DemangleOptions) (string, error) {
+	name := strings.TrimPrefix(sym, "_$s")
+	name = strings.TrimPrefix(name, "_T0")
+	return name, nil
+}
+
+// goDemangler recognizes Go symbols instantiated from a generic
+// function or method and simplifies their instantiation argument list.
+type goDemangler struct{}
+
+func (goDemangler) CanDemangle(sym string) bool {
+	return looksLikeGoGeneric(sym)
+}
+
+func (goDemangler) Demangle(sym string, opts plugin.DemangleOptions) (string, error) {
+	return demangleGoGeneric(sym, opts.Mode), nil
+}
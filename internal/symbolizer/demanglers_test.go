@@ -0,0 +1,155 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"testing"
+
+	"github.com/google/pprof/internal/plugin"
+)
+
+func TestCppDemanglerCanDemangle(t *testing.T) {
+	for _, c := range []struct {
+		sym  string
+		want bool
+	}{
+		{"_ZN3foo3barEv", true},
+		{"_GLOBAL__sub_I_main", true},
+		{"main.main", false},
+		{"_R", false},
+	} {
+		if got := (cppDemangler{}).CanDemangle(c.sym); got != c.want {
+			t.Errorf("cppDemangler.CanDemangle(%q) = %v, want %v", c.sym, got, c.want)
+		}
+	}
+}
+
+func TestCppDemangler(t *testing.T) {
+	for _, c := range []struct {
+		mode string
+		want string
+	}{
+		{"", "foo::bar"},
+		{"templates", "foo::bar"},
+		{"full", "foo::bar()"},
+	} {
+		got, err := (cppDemangler{}).Demangle("_ZN3foo3barEv", plugin.DemangleOptions{Mode: c.mode})
+		if err != nil {
+			t.Fatalf("Demangle(mode=%q) returned error: %v", c.mode, err)
+		}
+		if got != c.want {
+			t.Errorf("Demangle(mode=%q) = %q, want %q", c.mode, got, c.want)
+		}
+	}
+
+	if _, err := (cppDemangler{}).Demangle("main.main", plugin.DemangleOptions{}); err == nil {
+		t.Error("Demangle of a non-mangled name should have failed")
+	}
+}
+
+func TestRustDemanglerCanDemangle(t *testing.T) {
+	for _, c := range []struct {
+		sym  string
+		want bool
+	}{
+		{"_RNvC6crate412function", true},
+		{"_ZN3foo17h0123456789abcdefE", true},
+		{"_ZN3foo3barEv", false}, // Itanium C++, no legacy Rust hash.
+		{"main.main", false},
+	} {
+		if got := (rustDemangler{}).CanDemangle(c.sym); got != c.want {
+			t.Errorf("rustDemangler.CanDemangle(%q) = %v, want %v", c.sym, got, c.want)
+		}
+	}
+}
+
+func TestRustDemanglerLegacy(t *testing.T) {
+	sym := "_ZN3foo3bar17h0123456789abcdefE"
+	got, err := (rustDemangler{}).Demangle(sym, plugin.DemangleOptions{})
+	if err != nil {
+		t.Fatalf("Demangle returned error: %v", err)
+	}
+	if want := "foo::bar"; got != want {
+		t.Errorf("Demangle(%q) = %q, want %q", sym, got, want)
+	}
+
+	// "full" mode keeps the disambiguator hash.
+	got, err = (rustDemangler{}).Demangle(sym, plugin.DemangleOptions{Mode: "full"})
+	if err != nil {
+		t.Fatalf("Demangle(full) returned error: %v", err)
+	}
+	if want := "foo::bar::h0123456789abcdef"; got != want {
+		t.Errorf("Demangle(full)(%q) = %q, want %q", sym, got, want)
+	}
+}
+
+func TestRustDemanglerV0(t *testing.T) {
+	sym := "_R6crate48function"
+	got, err := (rustDemangler{}).Demangle(sym, plugin.DemangleOptions{})
+	if err != nil {
+		t.Fatalf("Demangle returned error: %v", err)
+	}
+	if want := "crate4::function"; got != want {
+		t.Errorf("Demangle(%q) = %q, want %q", sym, got, want)
+	}
+}
+
+func TestSwiftDemanglerCanDemangle(t *testing.T) {
+	for _, c := range []struct {
+		sym  string
+		want bool
+	}{
+		{"_$s4main3fooyyF", true},
+		{"_T0someSwiftName", true},
+		{"_ZN3foo3barEv", false},
+	} {
+		if got := (swiftDemangler{}).CanDemangle(c.sym); got != c.want {
+			t.Errorf("swiftDemangler.CanDemangle(%q) = %v, want %v", c.sym, got, c.want)
+		}
+	}
+}
+
+func TestGoDemanglerCanDemangle(t *testing.T) {
+	for _, c := range []struct {
+		sym  string
+		want bool
+	}{
+		{"pkg/path.Func[go.shape.int_0]", true},
+		{"main.main", false},
+	} {
+		if got := (goDemangler{}).CanDemangle(c.sym); got != c.want {
+			t.Errorf("goDemangler.CanDemangle(%q) = %v, want %v", c.sym, got, c.want)
+		}
+	}
+}
+
+// TestDefaultDemanglersOrder confirms rust is consulted before cpp, so
+// a legacy Rust symbol (valid Itanium C++ mangling too) doesn't get
+// picked up by cppDemangler first and left with its disambiguator hash.
+func TestDefaultDemanglersOrder(t *testing.T) {
+	demanglers := DefaultDemanglers()
+
+	sym := "_ZN3foo3bar17h0123456789abcdefE"
+	var d plugin.Demangler
+	for _, cand := range demanglers {
+		if cand.CanDemangle(sym) {
+			d = cand
+			break
+		}
+	}
+	if _, ok := d.(rustDemangler); !ok {
+		t.Fatalf("first demangler to claim %q was %T, want rustDemangler", sym, d)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"fmt"
+
+	"github.com/google/pprof/profile"
+)
+
+// MappingOutcome classifies what happened when Symbolizer attempted
+// to locally symbolize a single mapping.
+type MappingOutcome int
+
+const (
+	// OutcomeSymbolized means the mapping's debug info was found and
+	// at least one location resolved.
+	OutcomeSymbolized MappingOutcome = iota
+	// OutcomeSkippedAlready means the mapping already had symbol info
+	// and force wasn't set, so it was left untouched.
+	OutcomeSkippedAlready
+	// OutcomeMissingFile means the mapping had no associated file name.
+	OutcomeMissingFile
+	// OutcomeBuildIDMismatch means every file that could be opened for
+	// this mapping had a build ID that didn't match the profile's.
+	OutcomeBuildIDMismatch
+	// OutcomeOpenError means the mapping's file (and any debug-info
+	// candidates) failed to open.
+	OutcomeOpenError
+	// OutcomeNoAddrResolved means the mapping's debug info opened and
+	// its build ID matched, but addr2line didn't resolve any of its
+	// locations.
+	OutcomeNoAddrResolved
+)
+
+func (o MappingOutcome) String() string {
+	switch o {
+	case OutcomeSymbolized:
+		return "symbolized"
+	case OutcomeSkippedAlready:
+		return "skipped-already"
+	case OutcomeMissingFile:
+		return "missing-file"
+	case OutcomeBuildIDMismatch:
+		return "build-id-mismatch"
+	case OutcomeOpenError:
+		return "open-error"
+	case OutcomeNoAddrResolved:
+		return "no-addr-resolved"
+	default:
+		return "unknown"
+	}
+}
+
+// MappingReport records the outcome of attempting to locally
+// symbolize a single mapping.
+type MappingReport struct {
+	Mapping *profile.Mapping
+	Outcome MappingOutcome
+
+	// LocationsResolved and LocationsTotal count, among the profile's
+	// locations that fall within this mapping, how many got a
+	// non-empty inlined stack back from addr2line.
+	LocationsResolved int
+	LocationsTotal    int
+
+	// DebugInfoPath is the file that was actually opened to resolve
+	// this mapping; it may differ from Mapping.File when a
+	// DebugInfoLocator candidate (a dSYM bundle, say) was used instead.
+	// Empty unless Outcome is OutcomeSymbolized or OutcomeNoAddrResolved.
+	DebugInfoPath string
+}
+
+// SymbolizationReport records, per mapping, how local symbolization
+// went. Callers that need programmatic, mapping-by-mapping coverage
+// information, for example to fail a pipeline when coverage drops
+// below a threshold, should read this via Symbolizer.Report after
+// Symbolize returns, rather than scraping UI.PrintErr output.
+type SymbolizationReport struct {
+	Mappings []MappingReport
+}
+
+// Summary renders a one-line overview of the report, e.g.
+// "42/57 mappings symbolized; 3 build-ID mismatches; 2 missing binaries".
+// A mapping that was already symbolized and so didn't need attention
+// (OutcomeSkippedAlready) counts toward the numerator alongside
+// OutcomeSymbolized: both mean the mapping ended up with the symbol
+// information it needed.
+func (r *SymbolizationReport) Summary() string {
+	if r == nil || len(r.Mappings) == 0 {
+		return "no mappings to symbolize"
+	}
+
+	var satisfied, mismatches, missing, openErrors, noAddr int
+	for _, m := range r.Mappings {
+		switch m.Outcome {
+		case OutcomeSymbolized, OutcomeSkippedAlready:
+			satisfied++
+		case OutcomeBuildIDMismatch:
+			mismatches++
+		case OutcomeMissingFile:
+			missing++
+		case OutcomeOpenError:
+			openErrors++
+		case OutcomeNoAddrResolved:
+			noAddr++
+		}
+	}
+
+	s := fmt.Sprintf("%d/%d mappings symbolized", satisfied, len(r.Mappings))
+	if mismatches > 0 {
+		s += fmt.Sprintf("; %d build-ID mismatches", mismatches)
+	}
+	if missing > 0 {
+		s += fmt.Sprintf("; %d missing binaries", missing)
+	}
+	if openErrors > 0 {
+		s += fmt.Sprintf("; %d open errors", openErrors)
+	}
+	if noAddr > 0 {
+		s += fmt.Sprintf("; %d with no addresses resolved", noAddr)
+	}
+	return s
+}
+
+// Complete reports whether every mapping in r ended up with the
+// symbol information it needed, i.e. every outcome is
+// OutcomeSymbolized or OutcomeSkippedAlready.
+func (r *SymbolizationReport) Complete() bool {
+	if r == nil {
+		return true
+	}
+	for _, m := range r.Mappings {
+		if m.Outcome != OutcomeSymbolized && m.Outcome != OutcomeSkippedAlready {
+			return false
+		}
+	}
+	return true
+}
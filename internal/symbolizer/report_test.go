@@ -0,0 +1,76 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import "testing"
+
+func TestSummaryNoMappings(t *testing.T) {
+	var r *SymbolizationReport
+	if got, want := r.Summary(), "no mappings to symbolize"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	r = &SymbolizationReport{}
+	if got, want := r.Summary(), "no mappings to symbolize"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestSummaryCountsSkippedAlreadyAsSatisfied(t *testing.T) {
+	r := &SymbolizationReport{Mappings: []MappingReport{
+		{Outcome: OutcomeSkippedAlready},
+	}}
+	if got, want := r.Summary(), "1/1 mappings symbolized"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestSummaryMixedOutcomes(t *testing.T) {
+	r := &SymbolizationReport{Mappings: []MappingReport{
+		{Outcome: OutcomeSymbolized},
+		{Outcome: OutcomeSkippedAlready},
+		{Outcome: OutcomeBuildIDMismatch},
+		{Outcome: OutcomeMissingFile},
+		{Outcome: OutcomeOpenError},
+		{Outcome: OutcomeNoAddrResolved},
+	}}
+	want := "2/6 mappings symbolized; 1 build-ID mismatches; 1 missing binaries; 1 open errors; 1 with no addresses resolved"
+	if got := r.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	var nilReport *SymbolizationReport
+	if !nilReport.Complete() {
+		t.Error("a nil report should be Complete")
+	}
+
+	allGood := &SymbolizationReport{Mappings: []MappingReport{
+		{Outcome: OutcomeSymbolized},
+		{Outcome: OutcomeSkippedAlready},
+	}}
+	if !allGood.Complete() {
+		t.Error("a report with only symbolized/skipped-already mappings should be Complete")
+	}
+
+	incomplete := &SymbolizationReport{Mappings: []MappingReport{
+		{Outcome: OutcomeSymbolized},
+		{Outcome: OutcomeMissingFile},
+	}}
+	if incomplete.Complete() {
+		t.Error("a report with a missing-file mapping should not be Complete")
+	}
+}
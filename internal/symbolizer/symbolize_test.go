@@ -0,0 +1,84 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+// recordingUI is a fakeUI that records every PrintErr call instead of
+// discarding it, so tests can assert on what Symbolize surfaces.
+type recordingUI struct {
+	fakeUI
+	errs []string
+}
+
+func (u *recordingUI) PrintErr(args ...interface{}) {
+	u.errs = append(u.errs, fmt.Sprint(args...))
+}
+
+func TestSymbolizeDoesNotPrintForAlreadySymbolizedProfile(t *testing.T) {
+	prof := &profile.Profile{
+		Mapping: []*profile.Mapping{
+			{ID: 1, File: "/bin/foo", HasFunctions: true},
+		},
+	}
+	prof.Location = []*profile.Location{
+		{ID: 1, Mapping: prof.Mapping[0], Address: 0x1000},
+	}
+
+	ui := &recordingUI{}
+	s := &Symbolizer{Obj: &fakeObjTool{}, UI: ui, Cache: &SymCache{}}
+	if err := s.Symbolize("", nil, prof); err != nil {
+		t.Fatalf("Symbolize failed: %v", err)
+	}
+
+	if len(ui.errs) != 0 {
+		t.Errorf("Symbolize printed %v for a profile that was already symbolized", ui.errs)
+	}
+	if report := s.Report(); report == nil || !report.Complete() {
+		t.Errorf("Report() = %+v, want a Complete report", report)
+	}
+}
+
+func TestSymbolizePrintsForIncompleteProfile(t *testing.T) {
+	prof := &profile.Profile{
+		Mapping: []*profile.Mapping{
+			{ID: 1, File: ""}, // Missing file name: OutcomeMissingFile.
+		},
+	}
+	prof.Location = []*profile.Location{
+		{ID: 1, Mapping: prof.Mapping[0], Address: 0x1000},
+	}
+
+	ui := &recordingUI{}
+	s := &Symbolizer{Obj: &fakeObjTool{}, UI: ui, Cache: &SymCache{}}
+	if err := s.Symbolize("local", nil, prof); err != nil {
+		t.Fatalf("Symbolize failed: %v", err)
+	}
+
+	var sawSummary bool
+	for _, e := range ui.errs {
+		if e == s.Report().Summary() {
+			sawSummary = true
+		}
+	}
+	if !sawSummary {
+		t.Errorf("Symbolize didn't print the summary for an incomplete report; got %v", ui.errs)
+	}
+}
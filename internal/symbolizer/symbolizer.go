@@ -22,25 +22,54 @@ import (
 	"io/ioutil"
 	"net/http"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/pprof/internal/binutils"
 	"github.com/google/pprof/internal/plugin"
 	"github.com/google/pprof/internal/symbolz"
 	"github.com/google/pprof/profile"
-	"github.com/ianlancetaylor/demangle"
 )
 
 // Symbolizer implements the plugin.Symbolize interface.
 type Symbolizer struct {
 	Obj plugin.ObjTool
 	UI  plugin.UI
+
+	// Demanglers are consulted, in order, to simplify each function
+	// name; the first one whose CanDemangle returns true handles that
+	// name. A nil slice uses DefaultDemanglers.
+	Demanglers []plugin.Demangler
+
+	// DebugInfo resolves where the debug info for a mapping lives when
+	// it isn't in the mapped file itself (e.g. a dSYM bundle or a
+	// build-id debug directory). A nil value uses DefaultDebugInfoLocator.
+	DebugInfo plugin.DebugInfoLocator
+
+	// Cache is the on-disk symbolization cache consulted between
+	// localSymbolize and the underlying addr2liner. A nil value uses
+	// DefaultSymCache.
+	Cache *SymCache
+
+	report *SymbolizationReport
+}
+
+// Report returns the SymbolizationReport produced by the most recent
+// call to Symbolize, or nil if Symbolize hasn't been called, or didn't
+// attempt local symbolization.
+func (s *Symbolizer) Report() *SymbolizationReport {
+	return s.report
 }
 
 // Symbolize attempts to symbolize profile p. First uses binutils on
 // local binaries; if the source is a URL it attempts to get any
 // missed entries using symbolz.
 func (s *Symbolizer) Symbolize(mode string, sources plugin.MappingSources, p *profile.Profile) error {
+	s.report = nil // Report reflects only the most recent call.
+
 	remote, local, force, demanglerMode := true, true, false, ""
 	for _, o := range strings.Split(strings.ToLower(mode), ":") {
 		switch o {
@@ -53,8 +82,17 @@ func (s *Symbolizer) Symbolize(mode string, sources plugin.MappingSources, p *pr
 		case "", "force":
 			force = true
 		default:
+			if strings.HasPrefix(o, "jobs=") {
+				continue // Parsed again by localSymbolize.
+			}
+			if d := strings.TrimPrefix(o, "cache="); d != o {
+				switch d {
+				case "off", "ro", "rw":
+					continue // Parsed again by localSymbolize.
+				}
+			}
 			switch d := strings.TrimPrefix(o, "demangle="); d {
-			case "full", "none", "templates":
+			case "full", "none", "templates", "auto", "go", "rust", "swift":
 				demanglerMode = d
 				force = true
 				continue
@@ -62,15 +100,25 @@ func (s *Symbolizer) Symbolize(mode string, sources plugin.MappingSources, p *pr
 				continue
 			}
 			s.UI.PrintErr("ignoring unrecognized symbolization option: " + mode)
-			s.UI.PrintErr("expecting -symbolize=[local|fastlocal|remote|none][:force][:demangle=[none|full|templates|default]")
+			s.UI.PrintErr("expecting -symbolize=[local|fastlocal|remote|none][:force][:jobs=N][:cache=off|ro|rw][:demangle=[none|full|templates|auto|go|rust|swift|default]")
 		}
 	}
 
 	var err error
 	if local {
 		// Symbolize locally using binutils.
-		if err = localSymbolize(mode, p, s.Obj, s.UI); err == nil {
+		var report *SymbolizationReport
+		report, err = localSymbolize(mode, p, s.Obj, s.UI, s.DebugInfo, s.Cache)
+		s.report = report
+		if err == nil {
 			remote = false // Already symbolized, no need to apply remote symbolization.
+			if !report.Complete() {
+				// Only surface the summary when some mapping is missing,
+				// mismatched, or otherwise short of symbol info; a report
+				// where everything was symbolized or already had symbols
+				// isn't actionable and shouldn't print on every routine run.
+				s.UI.PrintErr(report.Summary())
+			}
 		}
 	}
 	if remote {
@@ -79,7 +127,7 @@ func (s *Symbolizer) Symbolize(mode string, sources plugin.MappingSources, p *pr
 		}
 	}
 
-	Demangle(p, force, demanglerMode)
+	Demangle(p, force, demanglerMode, s.Demanglers)
 	return nil
 }
 
@@ -99,8 +147,10 @@ func postURL(source, post string) ([]byte, error) {
 // localSymbolize adds symbol and line number information to all locations
 // in a profile. mode enables some options to control
 // symbolization.
-func localSymbolize(mode string, prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI) error {
+func localSymbolize(mode string, prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI, debugInfo plugin.DebugInfoLocator, cache *SymCache) (*SymbolizationReport, error) {
 	force := false
+	jobs := runtime.GOMAXPROCS(0)
+	cacheMode := "rw"
 	// Disable some mechanisms based on mode string.
 	for _, o := range strings.Split(strings.ToLower(mode), ":") {
 		switch {
@@ -110,72 +160,163 @@ func localSymbolize(mode string, prof *profile.Profile, obj plugin.ObjTool, ui p
 			if bu, ok := obj.(*binutils.Binutils); ok {
 				bu.SetFastSymbolization(true)
 			}
+		case strings.HasPrefix(o, "jobs="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(o, "jobs=")); err == nil && n > 0 {
+				jobs = n
+			}
+		case strings.HasPrefix(o, "cache="):
+			switch d := strings.TrimPrefix(o, "cache="); d {
+			case "off", "ro", "rw":
+				cacheMode = d
+			}
 		default:
 		}
 	}
+	if cache == nil {
+		cache = DefaultSymCache()
+	}
 
-	mt, err := newMapping(prof, obj, ui, force)
+	mt, report, err := newMapping(prof, obj, ui, force, debugInfo)
 	if err != nil {
-		return err
+		return report, err
 	}
 	defer mt.close()
 
-	functions := make(map[profile.Function]*profile.Function)
+	reportIdx := make(map[*profile.Mapping]int, len(report.Mappings))
+	for i, rep := range report.Mappings {
+		reportIdx[rep.Mapping] = i
+	}
+
+	// Group locations by mapping: each mapping's plugin.ObjFile owns
+	// its own addr2liner subprocess, so a worker resolving one mapping
+	// never shares a pipe with a worker resolving another.
+	byMapping := make(map[*profile.Mapping][]*profile.Location)
 	for _, l := range mt.prof.Location {
-		m := l.Mapping
-		segment := mt.segments[m]
-		if segment == nil {
-			// Nothing to do.
-			continue
+		if mt.segments[l.Mapping] != nil {
+			byMapping[l.Mapping] = append(byMapping[l.Mapping], l)
 		}
+	}
 
-		stack, err := segment.SourceLine(l.Address)
-		if err != nil || len(stack) == 0 {
-			// No answers from addr2line.
-			continue
-		}
+	// Sort mappings so that, regardless of which worker finishes first,
+	// the dedup pass below always visits them in the same order and
+	// assigns the same Function IDs.
+	mappings := make([]*profile.Mapping, 0, len(byMapping))
+	for m := range byMapping {
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].ID < mappings[j].ID })
 
-		l.Line = make([]profile.Line, len(stack))
-		for i, frame := range stack {
-			if frame.Func != "" {
-				m.HasFunctions = true
-			}
-			if frame.File != "" {
-				m.HasFilenames = true
-			}
-			if frame.Line != 0 {
-				m.HasLineNumbers = true
-			}
-			f := &profile.Function{
-				Name:       frame.Func,
-				SystemName: frame.Func,
-				Filename:   frame.File,
+	stacks := make(map[*profile.Location][]plugin.Frame, len(mt.prof.Location))
+	var stacksMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for _, m := range mappings {
+		segment := mt.segments[m]
+		locs := byMapping[m]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(m *profile.Mapping, segment plugin.ObjFile, locs []*profile.Location) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, l := range locs {
+				var stack []plugin.Frame
+				if cacheMode != "off" {
+					stack, _ = cache.Get(m.BuildID, l.Address)
+				}
+				if stack == nil {
+					s, err := segment.SourceLine(l.Address)
+					if err != nil || len(s) == 0 {
+						// No answers from addr2line.
+						continue
+					}
+					stack = s
+					if cacheMode == "rw" {
+						cache.Put(m.BuildID, l.Address, stack)
+					}
+				}
+				stacksMu.Lock()
+				stacks[l] = stack
+				stacksMu.Unlock()
 			}
-			if fp := functions[*f]; fp != nil {
-				f = fp
-			} else {
-				functions[*f] = f
-				f.ID = uint64(len(mt.prof.Function)) + 1
-				mt.prof.Function = append(mt.prof.Function, f)
+		}(m, segment, locs)
+	}
+	wg.Wait()
+
+	// Record per-mapping location coverage, downgrading a mapping from
+	// symbolized to no-addr-resolved if its debug info opened but
+	// addr2line didn't answer for any of its locations.
+	resolved := make(map[*profile.Mapping]int)
+	for l, stack := range stacks {
+		if len(stack) > 0 {
+			resolved[l.Mapping]++
+		}
+	}
+	for _, m := range mappings {
+		idx := reportIdx[m]
+		n := resolved[m]
+		report.Mappings[idx].LocationsResolved = n
+		if n == 0 && report.Mappings[idx].LocationsTotal > 0 {
+			report.Mappings[idx].Outcome = OutcomeNoAddrResolved
+		}
+	}
+
+	// Perform the profile.Function dedup on the main goroutine, under
+	// a single map, visiting mappings (and locations within a mapping)
+	// in a fixed order so Function IDs come out deterministic.
+	functions := make(map[profile.Function]*profile.Function)
+	for _, m := range mappings {
+		for _, l := range byMapping[m] {
+			stack := stacks[l]
+			if len(stack) == 0 {
+				continue
 			}
-			l.Line[i] = profile.Line{
-				Function: f,
-				Line:     int64(frame.Line),
+
+			l.Line = make([]profile.Line, len(stack))
+			for i, frame := range stack {
+				if frame.Func != "" {
+					m.HasFunctions = true
+				}
+				if frame.File != "" {
+					m.HasFilenames = true
+				}
+				if frame.Line != 0 {
+					m.HasLineNumbers = true
+				}
+				f := &profile.Function{
+					Name:       frame.Func,
+					SystemName: frame.Func,
+					Filename:   frame.File,
+				}
+				if fp := functions[*f]; fp != nil {
+					f = fp
+				} else {
+					functions[*f] = f
+					f.ID = uint64(len(mt.prof.Function)) + 1
+					mt.prof.Function = append(mt.prof.Function, f)
+				}
+				l.Line[i] = profile.Line{
+					Function: f,
+					Line:     int64(frame.Line),
+				}
 			}
-		}
 
-		if len(stack) > 0 {
 			m.HasInlineFrames = true
 		}
 	}
 
-	return nil
+	return report, nil
 }
 
-// Demangle updates the function names in a profile with demangled C++
+// Demangle updates the function names in a profile with demangled
 // names, simplified according to demanglerMode. If force is set,
-// overwrite any names that appear already demangled.
-func Demangle(prof *profile.Profile, force bool, demanglerMode string) {
+// overwrite any names that appear already demangled. Each function's
+// SystemName is offered to demanglers (or DefaultDemanglers if
+// demanglers is nil) in order; the first one that claims it wins. A
+// demanglerMode of "go", "rust", or "swift" bypasses CanDemangle and
+// forces every name through that single language's demangler, for
+// profiles symbolz/addr2line can't otherwise tell apart; "auto" (and
+// the default "") autodetect across the full list.
+func Demangle(prof *profile.Profile, force bool, demanglerMode string, demanglers []plugin.Demangler) {
 	if force {
 		// Remove the current demangled names to force demangling
 		for _, f := range prof.Function {
@@ -184,43 +325,158 @@ func Demangle(prof *profile.Profile, force bool, demanglerMode string) {
 			}
 		}
 	}
+	if demanglerMode == "none" {
+		return
+	}
+
+	if demanglers == nil {
+		demanglers = DefaultDemanglers()
+	}
 
-	var options []demangle.Option
+	var forced plugin.Demangler
 	switch demanglerMode {
-	case "": // demangled, simplified: no parameters, no templates, no return type
-		options = []demangle.Option{demangle.NoParams, demangle.NoTemplateParams}
-	case "templates": // demangled, simplified: no parameters, no return type
-		options = []demangle.Option{demangle.NoParams}
-	case "full":
-		options = []demangle.Option{demangle.NoClones}
-	case "none": // no demangling
-		return
+	case "go":
+		forced = goDemangler{}
+	case "rust":
+		forced = rustDemangler{}
+	case "swift":
+		forced = swiftDemangler{}
+	}
+
+	opts := plugin.DemangleOptions{Mode: demanglerMode}
+	if forced != nil {
+		opts.Mode = "" // "go"/"rust"/"swift" pick the demangler, not the simplification level.
 	}
 
-	// Copy the options because they may be updated by the call.
-	o := make([]demangle.Option, len(options))
 	for _, fn := range prof.Function {
 		if fn.Name != "" && fn.SystemName != fn.Name {
 			continue // Already demangled.
 		}
-		copy(o, options)
-		if demangled := demangle.Filter(fn.SystemName, o...); demangled != fn.SystemName {
-			fn.Name = demangled
-			continue
+
+		d := forced
+		if d == nil {
+			for _, cand := range demanglers {
+				if cand.CanDemangle(fn.SystemName) {
+					d = cand
+					break
+				}
+			}
 		}
-		// Could not demangle. Apply heuristics in case the name is
-		// already demangled.
-		name := fn.SystemName
-		if looksLikeDemangledCPlusPlus(name) {
-			if demanglerMode == "" || demanglerMode == "templates" {
-				name = removeMatching(name, '(', ')')
+		if d != nil {
+			if demangled, err := d.Demangle(fn.SystemName, opts); err == nil {
+				fn.Name = demangled
+				continue
 			}
-			if demanglerMode == "" {
-				name = removeMatching(name, '<', '>')
+		}
+
+		// None of the demanglers recognized this symbol (or the forced
+		// one failed); fall back to the legacy heuristics for names
+		// that already look demangled.
+		fn.Name = demangleFallback(fn.SystemName, opts.Mode)
+	}
+}
+
+// demangleFallback applies the pre-registry string heuristics for a
+// name that didn't match any registered Demangler, in case it's
+// already demangled (e.g. by a tool upstream of pprof).
+func demangleFallback(name string, demanglerMode string) string {
+	if looksLikeDemangledCPlusPlus(name) {
+		if demanglerMode == "" || demanglerMode == "templates" || demanglerMode == "auto" {
+			name = removeMatching(name, '(', ')')
+		}
+		if demanglerMode == "" || demanglerMode == "auto" {
+			name = removeMatching(name, '<', '>')
+		}
+	}
+	return name
+}
+
+// goShapePrefix is the prefix the compiler gives the instantiation
+// placeholder types it substitutes for a generic function or
+// method's type arguments, e.g. "go.shape.int_0".
+const goShapePrefix = "go.shape."
+
+// looksLikeGoGeneric is a heuristic to decide if a name is a Go
+// symbol for a function or method instantiated from a generic,
+// rather than a mangled C++ name. Go prints instantiation arguments
+// in "[...]" after a dotted import path; it never uses "<...>" or
+// "::", which a demangled C++ name would.
+func looksLikeGoGeneric(name string) bool {
+	if strings.Contains(name, "::") || strings.ContainsAny(name, "<>") {
+		return false
+	}
+	open := strings.IndexByte(name, '[')
+	return open > 0 && strings.Contains(name[:open], ".")
+}
+
+// demangleGoGeneric simplifies the name of a Go function or method
+// instantiated from a generic, e.g. "pkg/path.Func[go.shape.int_0]"
+// or "pkg/path.Type[...].Method". It always strips the
+// compiler-internal go.shape.* placeholders from the instantiation
+// argument list; in the default and templates modes it goes further
+// and collapses the list down to "[...]", the way the Go runtime
+// prints generic frames. The collapse happens whenever the original
+// list was non-empty, even if stripping the go.shape.* placeholders
+// leaves nothing behind (the common case, since most instantiation
+// arguments are placeholders): only a list that was already empty
+// collapses to "[]".
+func demangleGoGeneric(name string, demanglerMode string) string {
+	var out strings.Builder
+	for {
+		open := strings.IndexByte(name, '[')
+		if open < 0 {
+			out.WriteString(name)
+			break
+		}
+		close := matchingBracket(name, open)
+		if close < 0 {
+			out.WriteString(name)
+			break
+		}
+		out.WriteString(name[:open])
+		orig := name[open+1 : close]
+		args := stripGoShapeArgs(orig)
+		if demanglerMode == "full" {
+			out.WriteString("[" + args + "]")
+		} else if orig == "" {
+			out.WriteString("[]")
+		} else {
+			out.WriteString("[...]")
+		}
+		name = name[close+1:]
+	}
+	return out.String()
+}
+
+// matchingBracket returns the index of the ']' that closes the '['
+// at name[open], or -1 if the brackets are unbalanced.
+func matchingBracket(name string, open int) int {
+	nesting := 0
+	for i := open; i < len(name); i++ {
+		switch name[i] {
+		case '[':
+			nesting++
+		case ']':
+			nesting--
+			if nesting == 0 {
+				return i
 			}
 		}
-		fn.Name = name
 	}
+	return -1
+}
+
+// stripGoShapeArgs removes go.shape.* instantiation placeholders from
+// a comma-separated instantiation argument list.
+func stripGoShapeArgs(args string) string {
+	parts := strings.Split(args, ",")
+	kept := parts[:0]
+	for _, p := range parts {
+		if !strings.HasPrefix(strings.TrimSpace(p), goShapePrefix) {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, ",")
 }
 
 // looksLikeDemangledCPlusPlus is a heuristic to decide if a name is
@@ -259,27 +515,38 @@ func removeMatching(name string, start, end byte) string {
 	return name
 }
 
-// newMapping creates a mappingTable for a profile.
-func newMapping(prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI, force bool) (*mappingTable, error) {
+// newMapping creates a mappingTable for a profile, along with a
+// SymbolizationReport recording the outcome for every mapping it
+// considered.
+func newMapping(prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI, force bool, locator plugin.DebugInfoLocator) (*mappingTable, *SymbolizationReport, error) {
+	if locator == nil {
+		locator = DefaultDebugInfoLocator()
+	}
+
 	mt := &mappingTable{
 		prof:     prof,
 		segments: make(map[*profile.Mapping]plugin.ObjFile),
 	}
+	report := &SymbolizationReport{}
 
-	// Identify used mappings
-	mappings := make(map[*profile.Mapping]bool)
+	// Count locations per mapping, both to identify which mappings are
+	// actually used and to fill in each report entry's LocationsTotal.
+	locCounts := make(map[*profile.Mapping]int)
 	for _, l := range prof.Location {
-		mappings[l.Mapping] = true
+		locCounts[l.Mapping]++
 	}
 
 	missingBinaries := false
 	for midx, m := range prof.Mapping {
-		if !mappings[m] {
+		if locCounts[m] == 0 {
 			continue
 		}
+		rep := MappingReport{Mapping: m, LocationsTotal: locCounts[m]}
 
 		// Do not attempt to re-symbolize a mapping that has already been symbolized.
 		if !force && (m.HasFunctions || m.HasFilenames || m.HasLineNumbers) {
+			rep.Outcome = OutcomeSkippedAlready
+			report.Mappings = append(report.Mappings, rep)
 			continue
 		}
 
@@ -287,9 +554,11 @@ func newMapping(prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI, force b
 			if midx == 0 {
 				ui.PrintErr("Main binary filename not available.\n" +
 					"Try passing the path to the main binary before the profile.")
-				continue
+			} else {
+				missingBinaries = true
 			}
-			missingBinaries = true
+			rep.Outcome = OutcomeMissingFile
+			report.Mappings = append(report.Mappings, rep)
 			continue
 		}
 
@@ -299,23 +568,55 @@ func newMapping(prof *profile.Profile, obj plugin.ObjTool, ui plugin.UI, force b
 			continue
 		}
 
-		f, err := obj.Open(m.File, m.Start, m.Limit, m.Offset)
+		f, path, outcome, err := openMappingFile(obj, locator, m)
 		if err != nil {
 			ui.PrintErr("Local symbolization failed for ", name, ": ", err)
-			continue
-		}
-		if fid := f.BuildID(); m.BuildID != "" && fid != "" && fid != m.BuildID {
-			ui.PrintErr("Local symbolization failed for ", name, ": build ID mismatch")
-			f.Close()
+			rep.Outcome = outcome
+			report.Mappings = append(report.Mappings, rep)
 			continue
 		}
 
+		rep.Outcome = OutcomeSymbolized
+		rep.DebugInfoPath = path
+		report.Mappings = append(report.Mappings, rep)
 		mt.segments[m] = f
 	}
 	if missingBinaries {
 		ui.PrintErr("Some binary filenames not available. Symbolization may be incomplete.")
 	}
-	return mt, nil
+	return mt, report, nil
+}
+
+// openMappingFile opens m's own file and, if that fails or its build
+// ID doesn't match m.BuildID, falls back through locator's candidate
+// debug-info paths (macOS dSYM bundles, ELF debuglink/build-id
+// directories) until one opens with a matching build ID. The
+// build-ID mismatch check is applied to whichever file actually ends
+// up open, which may not be m.File; the path of that file and the
+// resulting outcome are returned alongside it for SymbolizationReport.
+func openMappingFile(obj plugin.ObjTool, locator plugin.DebugInfoLocator, m *profile.Mapping) (plugin.ObjFile, string, MappingOutcome, error) {
+	candidates := append([]string{m.File}, locator.Locate(m.File, m.BuildID)...)
+
+	var lastErr error
+	sawMismatch := false
+	for _, path := range candidates {
+		f, err := obj.Open(path, m.Start, m.Limit, m.Offset)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if fid := f.BuildID(); m.BuildID != "" && fid != "" && fid != m.BuildID {
+			f.Close()
+			lastErr = fmt.Errorf("build ID mismatch")
+			sawMismatch = true
+			continue
+		}
+		return f, path, OutcomeSymbolized, nil
+	}
+	if sawMismatch {
+		return nil, "", OutcomeBuildIDMismatch, lastErr
+	}
+	return nil, "", OutcomeOpenError, lastErr
 }
 
 // mappingTable contains the mechanisms for symbolization of a
@@ -0,0 +1,166 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/google/pprof/internal/plugin"
+	"github.com/google/pprof/profile"
+)
+
+// fakeUI is a plugin.UI that discards everything.
+type fakeUI struct{}
+
+func (fakeUI) ReadLine(prompt string) (string, error)       { return "", fmt.Errorf("no input") }
+func (fakeUI) Print(...interface{})                         {}
+func (fakeUI) PrintErr(...interface{})                      {}
+func (fakeUI) IsTerminal() bool                             { return false }
+func (fakeUI) WantBrowser() bool                            { return false }
+func (fakeUI) SetAutoComplete(complete func(string) string) {}
+
+// fakeObjFile is a plugin.ObjFile backed by an in-memory address to
+// inlined-stack table, standing in for addr2liner during tests.
+type fakeObjFile struct {
+	name    string
+	buildID string
+	lines   map[uint64][]plugin.Frame
+}
+
+func (f *fakeObjFile) Name() string                        { return f.name }
+func (f *fakeObjFile) ObjAddr(addr uint64) (uint64, error) { return addr, nil }
+func (f *fakeObjFile) BuildID() string                     { return f.buildID }
+func (f *fakeObjFile) SourceLine(addr uint64) ([]plugin.Frame, error) {
+	return f.lines[addr], nil
+}
+func (f *fakeObjFile) Symbols(r *regexp.Regexp, addr uint64) ([]*plugin.Sym, error) {
+	return nil, nil
+}
+func (f *fakeObjFile) Close() error { return nil }
+
+// fakeObjTool is a plugin.ObjTool that serves pre-built fakeObjFiles by
+// path, ignoring the start/limit/offset load-segment arguments.
+type fakeObjTool struct {
+	files map[string]*fakeObjFile
+}
+
+func (t *fakeObjTool) Open(file string, start, limit, offset uint64) (plugin.ObjFile, error) {
+	f, ok := t.files[file]
+	if !ok {
+		return nil, fmt.Errorf("no such file: %s", file)
+	}
+	return f, nil
+}
+
+func (t *fakeObjTool) Disasm(file string, start, end uint64, intelSyntax bool) ([]plugin.Inst, error) {
+	return nil, fmt.Errorf("Disasm unsupported")
+}
+
+// buildMultiMappingProfile returns a profile with nMappings mappings,
+// each owning locsPerMapping locations, plus the fakeObjTool that
+// resolves every one of those locations to a distinct inlined stack.
+func buildMultiMappingProfile(nMappings, locsPerMapping int) (*profile.Profile, *fakeObjTool) {
+	prof := &profile.Profile{}
+	tool := &fakeObjTool{files: make(map[string]*fakeObjFile)}
+
+	for i := 0; i < nMappings; i++ {
+		file := fmt.Sprintf("/bin/mapping%d", i)
+		buildID := fmt.Sprintf("build%d", i)
+		m := &profile.Mapping{
+			ID:      uint64(i + 1),
+			Start:   uint64(i) * 0x10000,
+			Limit:   uint64(i)*0x10000 + 0x10000,
+			File:    file,
+			BuildID: buildID,
+		}
+		prof.Mapping = append(prof.Mapping, m)
+
+		obj := &fakeObjFile{name: file, buildID: buildID, lines: make(map[uint64][]plugin.Frame)}
+		tool.files[file] = obj
+
+		for j := 0; j < locsPerMapping; j++ {
+			addr := m.Start + uint64(j)
+			obj.lines[addr] = []plugin.Frame{{
+				Func: fmt.Sprintf("mapping%d.Func%d", i, j),
+				File: fmt.Sprintf("mapping%d.go", i),
+				Line: j + 1,
+			}}
+			prof.Location = append(prof.Location, &profile.Location{
+				ID:      uint64(len(prof.Location) + 1),
+				Mapping: m,
+				Address: addr,
+			})
+		}
+	}
+	return prof, tool
+}
+
+// canonicalFunctionNames returns, for every location in prof, the
+// resolved function name, sorted so the comparison doesn't depend on
+// the order Function IDs were assigned in.
+func canonicalFunctionNames(prof *profile.Profile) []string {
+	var names []string
+	for _, l := range prof.Location {
+		for _, line := range l.Line {
+			names = append(names, line.Function.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestLocalSymbolizeParallelMatchesSerial(t *testing.T) {
+	parallelProf, parallelTool := buildMultiMappingProfile(8, 4)
+	if _, err := localSymbolize("", parallelProf, parallelTool, fakeUI{}, nil, &SymCache{}); err != nil {
+		t.Fatalf("localSymbolize (parallel) failed: %v", err)
+	}
+
+	serialProf, serialTool := buildMultiMappingProfile(8, 4)
+	if _, err := localSymbolize("jobs=1", serialProf, serialTool, fakeUI{}, nil, &SymCache{}); err != nil {
+		t.Fatalf("localSymbolize (serial) failed: %v", err)
+	}
+
+	parallelNames := canonicalFunctionNames(parallelProf)
+	serialNames := canonicalFunctionNames(serialProf)
+	if len(parallelNames) != len(serialNames) {
+		t.Fatalf("got %d resolved locations in parallel run, %d in serial run", len(parallelNames), len(serialNames))
+	}
+	for i := range parallelNames {
+		if parallelNames[i] != serialNames[i] {
+			t.Fatalf("canonicalized function names diverge at %d: parallel=%q serial=%q", i, parallelNames[i], serialNames[i])
+		}
+	}
+}
+
+func BenchmarkLocalSymbolizeParallel(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		prof, tool := buildMultiMappingProfile(16, 64)
+		if _, err := localSymbolize("", prof, tool, fakeUI{}, nil, &SymCache{}); err != nil {
+			b.Fatalf("localSymbolize failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkLocalSymbolizeSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		prof, tool := buildMultiMappingProfile(16, 64)
+		if _, err := localSymbolize("jobs=1", prof, tool, fakeUI{}, nil, &SymCache{}); err != nil {
+			b.Fatalf("localSymbolize failed: %v", err)
+		}
+	}
+}
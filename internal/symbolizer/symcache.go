@@ -0,0 +1,201 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/pprof/internal/plugin"
+)
+
+// SymCache is an on-disk, content-addressed cache of symbolization
+// results, keyed by (BuildID, Address). It lets repeated pprof runs
+// against the same binaries, common during iterative profiling
+// sessions and CI flame-graph generation, skip the addr2line
+// round-trip entirely on a cache hit.
+type SymCache struct {
+	// Dir is the cache's root directory. An empty Dir disables the cache.
+	Dir string
+}
+
+// DefaultSymCache returns the default on-disk symbolization cache:
+// $PPROF_SYMCACHE if set, otherwise $XDG_CACHE_HOME/pprof/symcache,
+// falling back to $HOME/.cache/pprof/symcache.
+func DefaultSymCache() *SymCache {
+	if dir := os.Getenv("PPROF_SYMCACHE"); dir != "" {
+		return &SymCache{Dir: dir}
+	}
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return &SymCache{}
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return &SymCache{Dir: filepath.Join(base, "pprof", "symcache")}
+}
+
+func (c *SymCache) path(buildID string, addr uint64) string {
+	return filepath.Join(c.Dir, buildID, fmt.Sprintf("%016x", addr))
+}
+
+// validBuildID reports whether buildID is safe to use as a single
+// path component under c.Dir. profile.Mapping.BuildID comes straight
+// from the profile being symbolized, which may be untrusted, so a
+// buildID containing a path separator or a ".." segment (e.g. to
+// escape c.Dir) is rejected rather than passed to filepath.Join.
+func validBuildID(buildID string) bool {
+	return buildID != "" && buildID != "." && buildID != ".." &&
+		!strings.ContainsAny(buildID, "/\\")
+}
+
+// Get returns the cached inlined stack for (buildID, addr), if present.
+func (c *SymCache) Get(buildID string, addr uint64) ([]plugin.Frame, bool) {
+	if c == nil || c.Dir == "" || !validBuildID(buildID) {
+		return nil, false
+	}
+	f, err := os.Open(c.path(buildID, addr))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	stack, err := decodeStack(f)
+	if err != nil {
+		return nil, false
+	}
+	return stack, true
+}
+
+// Put stores the resolved inlined stack for (buildID, addr).
+func (c *SymCache) Put(buildID string, addr uint64, stack []plugin.Frame) error {
+	if c == nil || c.Dir == "" || !validBuildID(buildID) {
+		return nil
+	}
+	path := c.path(buildID, addr)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// Concurrent Put calls (one per worker goroutine) can target the
+	// same (buildID, addr) key, e.g. the same shared library mapped by
+	// several processes in a merged profile; os.CreateTemp gives each
+	// writer its own file so they can't interleave writes to the same
+	// temp path before the rename.
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := encodeStack(f, stack); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Prune deletes every entry in the cache. It is the maintenance entry
+// point the driver wires up to the "pprof --symcache-prune" flag.
+func (c *SymCache) Prune() error {
+	if c == nil || c.Dir == "" {
+		return nil
+	}
+	return os.RemoveAll(c.Dir)
+}
+
+// encodeStack writes stack as a varint frame count followed by, per
+// frame, length-prefixed Func and File strings and a varint Line.
+func encodeStack(w io.Writer, stack []plugin.Frame) error {
+	bw := bufio.NewWriter(w)
+	if err := writeUvarint(bw, uint64(len(stack))); err != nil {
+		return err
+	}
+	for _, fr := range stack {
+		if err := writeString(bw, fr.Func); err != nil {
+			return err
+		}
+		if err := writeString(bw, fr.File); err != nil {
+			return err
+		}
+		if err := writeUvarint(bw, uint64(fr.Line)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// decodeStack is the inverse of encodeStack.
+func decodeStack(r io.Reader) ([]plugin.Frame, error) {
+	br := bufio.NewReader(r)
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	stack := make([]plugin.Frame, n)
+	for i := range stack {
+		fn, err := readString(br)
+		if err != nil {
+			return nil, err
+		}
+		file, err := readString(br)
+		if err != nil {
+			return nil, err
+		}
+		line, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		stack[i] = plugin.Frame{Func: fn, File: file, Line: int(line)}
+	}
+	return stack, nil
+}
+
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := writeUvarint(w, uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
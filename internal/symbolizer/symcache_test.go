@@ -0,0 +1,150 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package symbolizer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/internal/plugin"
+)
+
+func TestSymCacheRoundTrip(t *testing.T) {
+	c := &SymCache{Dir: t.TempDir()}
+
+	stack := []plugin.Frame{
+		{Func: "pkg.Outer", File: "outer.go", Line: 10},
+		{Func: "pkg.Inner", File: "inner.go", Line: 20},
+	}
+	if err := c.Put("build1", 0x1000, stack); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	got, ok := c.Get("build1", 0x1000)
+	if !ok {
+		t.Fatal("Get reported a miss for a key that was just Put")
+	}
+	if !reflect.DeepEqual(got, stack) {
+		t.Fatalf("Get returned %+v, want %+v", got, stack)
+	}
+
+	// A different build ID, or a different address within the same
+	// build ID, must not collide with the entry above.
+	if _, ok := c.Get("build2", 0x1000); ok {
+		t.Fatal("Get hit for a build ID that was never Put")
+	}
+	if _, ok := c.Get("build1", 0x2000); ok {
+		t.Fatal("Get hit for an address that was never Put")
+	}
+}
+
+func TestSymCacheDisabled(t *testing.T) {
+	var c *SymCache
+	if err := c.Put("build1", 0x1000, []plugin.Frame{{Func: "pkg.Func"}}); err != nil {
+		t.Fatalf("Put on a nil *SymCache should be a no-op, got error: %v", err)
+	}
+	if _, ok := c.Get("build1", 0x1000); ok {
+		t.Fatal("Get on a nil *SymCache should always miss")
+	}
+
+	c = &SymCache{}
+	if err := c.Put("build1", 0x1000, []plugin.Frame{{Func: "pkg.Func"}}); err != nil {
+		t.Fatalf("Put with an empty Dir should be a no-op, got error: %v", err)
+	}
+	if _, ok := c.Get("build1", 0x1000); ok {
+		t.Fatal("Get with an empty Dir should always miss")
+	}
+}
+
+func TestSymCacheRejectsUnsafeBuildID(t *testing.T) {
+	dir := t.TempDir()
+	c := &SymCache{Dir: dir}
+
+	for _, buildID := range []string{
+		"../../../../../../tmp/x",
+		"..",
+		".",
+		"a/b",
+		`a\b`,
+		"",
+	} {
+		if err := c.Put(buildID, 0x1000, []plugin.Frame{{Func: "pkg.Func"}}); err != nil {
+			t.Fatalf("Put(%q) returned error: %v", buildID, err)
+		}
+		if _, ok := c.Get(buildID, 0x1000); ok {
+			t.Errorf("Get(%q) hit after a Put that should have been rejected", buildID)
+		}
+	}
+
+	// Nothing should have been written outside (or even inside) dir.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("cache dir is not empty after rejected Puts: %v", entries)
+	}
+	if _, err := os.Stat("/tmp/x"); err == nil {
+		t.Fatal("Put escaped the cache directory via a path-traversal build ID")
+	}
+}
+
+func TestSymCachePrune(t *testing.T) {
+	c := &SymCache{Dir: t.TempDir()}
+	if err := c.Put("build1", 0x1000, []plugin.Frame{{Func: "pkg.Func"}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := c.Prune(); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if _, ok := c.Get("build1", 0x1000); ok {
+		t.Fatal("Get hit after Prune")
+	}
+}
+
+// slowResolve simulates the cost of an addr2line round-trip, so the
+// benchmarks below can demonstrate the wall-clock improvement a cache
+// hit gives a second symbolization of the same binary.
+func slowResolve(addr uint64) []plugin.Frame {
+	time.Sleep(time.Millisecond)
+	return []plugin.Frame{{Func: "pkg.Func", File: "pkg.go", Line: int(addr)}}
+}
+
+func BenchmarkSymbolizeColdCache(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		c := &SymCache{Dir: b.TempDir()}
+		for addr := uint64(0); addr < 32; addr++ {
+			stack := slowResolve(addr)
+			c.Put("build1", addr, stack)
+		}
+	}
+}
+
+func BenchmarkSymbolizeWarmCache(b *testing.B) {
+	c := &SymCache{Dir: b.TempDir()}
+	for addr := uint64(0); addr < 32; addr++ {
+		c.Put("build1", addr, slowResolve(addr))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for addr := uint64(0); addr < 32; addr++ {
+			if _, ok := c.Get("build1", addr); !ok {
+				b.Fatalf("unexpected cache miss for addr %d", addr)
+			}
+		}
+	}
+}